@@ -1,5 +1,11 @@
 package bplus
 
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
 type fillState int
 
 const (
@@ -8,290 +14,343 @@ const (
 	nodeFull                           // The node is completely full
 )
 
-type (
-	Key   []byte // A byte-slice representing a key in a B+ tree
-	Value []byte // A byte-slice representing a leaf-node key's value in a B+ tree
-)
-
-// BPlusTree
-type BPlusTree struct {
-	order int  // Max number of keys per node
-	root  node // The root node (either leaf or internal)
+// BPlusTree is a B+ tree mapping keys of type K to values of
+// type V. Keys are ordered using a user-supplied Less function
+// rather than a builtin ordering, so K need not satisfy Go's
+// `cmp.Ordered`/`comparable` constraints.
+//
+// For the original []byte-keyed API, see the bytes sub-package.
+type BPlusTree[K, V any] struct {
+	mu sync.Mutex // Serializes Insert, Update, Delete, Snapshot, and Clone
+
+	// snapGen counts how many Snapshot/Clone calls t has ever
+	// served. Insert, Update, Delete, and DeleteOne capture its
+	// current value as the generation they mutate under and
+	// pass it down through the node tree; a node's ownerGen is
+	// the generation that last owned it, so a node from an
+	// earlier generation is known to be reachable from a
+	// still-open Snapshot or Clone and must be copied rather
+	// than mutated. See ensureOwned on leafNode/internalNode.
+	snapGen uint64
+
+	order           int             // Max number of keys per node
+	less            func(K, K) bool // Reports whether a sorts before b
+	allowDuplicates bool            // Whether multiple entries may share a key
+	root            node[K, V]      // The root node (either leaf or internal)
+
+	store      PageStore // Backing page store, or nil for an in-memory tree
+	keyCodec   *Codec[K] // Encodes/decodes keys for store, nil for an in-memory tree
+	valueCodec *Codec[V] // Encodes/decodes values for store, nil for an in-memory tree
 }
 
-// NewBPlusTree creates a new B+ tree with the specified order.
-func NewBPlusTree(order int) *BPlusTree {
+// NewBPlusTree creates a new in-memory B+ tree with the
+// specified order, ordering keys with less. If allowDuplicates
+// is false, Insert overwrites the value of an existing matching
+// key instead of adding a second entry for it; if true, Insert
+// and InsertMulti both append a new entry, and SearchAll,
+// DeleteOne, and DeleteAll can be used to work with the
+// resulting runs of equal keys.
+func NewBPlusTree[K, V any](order int, less func(a, b K) bool, allowDuplicates bool) *BPlusTree[K, V] {
 	if order <= 0 {
 		panic("tree order must be >= 1")
 	}
-	return &BPlusTree{
-		order: order,
-		root:  newLeafNode(order),
+	if less == nil {
+		panic("less must not be nil")
+	}
+	root, _ := newLeafNode[K, V](order, less, allowDuplicates, 0, nil, nil, nil) // err is always nil for an in-memory leaf
+	return &BPlusTree[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		root:            root,
 	}
 }
 
 // Order returns the B+ tree's order (the max number of keys
 // allowes per node).
-func (t *BPlusTree) Order() int {
+func (t *BPlusTree[K, V]) Order() int {
 	return t.order
 }
 
 // GetKeys returns a slice of all keys in the tree
-func (t *BPlusTree) GetKeys() ([]Key, error) {
+func (t *BPlusTree[K, V]) GetKeys() ([]K, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.root.GetKeys()
 }
 
 // GetValues returns a slice of all values in the tree
-func (t *BPlusTree) GetValues() ([]Value, error) {
+func (t *BPlusTree[K, V]) GetValues() ([]V, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.root.GetValues()
 }
 
 // Search returns the first value with the matching
 // key in the tree
-func (t *BPlusTree) Search(k Key) (Value, error) {
+func (t *BPlusTree[K, V]) Search(k K) (V, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.root.Search(k)
 }
 
-// SearchRange returns all values with a key
-func (t *BPlusTree) SearchRange(k1, k2 Key) ([]Value, error) {
+// SearchRange returns all values with a key in the inclusive
+// range [k1, k2]
+func (t *BPlusTree[K, V]) SearchRange(k1, k2 K) ([]V, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	return t.root.SearchRange(k1, k2)
 }
 
-// Insert adds a new value to the tree, at the given key
-func (t *BPlusTree) Insert(k Key, v Value) error {
-	return t.root.Insert(k, v)
-}
-
-// Update sets the value for all matching keys in the tree
-func (t *BPlusTree) Update(k Key, v Value) error {
-	return t.root.Update(k, v)
-}
-
-// Delete removes all key-value pairs from the tree with
-// a matching key
-func (t *BPlusTree) Delete(k Key) error {
-	return t.root.Delete(k)
-}
-
-// node represents a node (either internal or leaf)
-// in the B+ tree.
-type node interface {
-	// GetKeys returns a slice of all keys in the tree
-	GetKeys() ([]Key, error)
-
-	// GetValues returns a slice of all values in the tree
-	GetValues() ([]Value, error)
-
-	// Search returns the first value with the matching
-	// key in the tree
-	Search(Key) (Value, error)
-
-	// SearchRange returns all values with a key
-	SearchRange(Key, Key) ([]Value, error)
-
-	// Insert adds a new value to the tree, at the given key
-	Insert(Key, Value) error
-
-	// Update sets the value for all matching keys in the tree
-	Update(Key, Value) error
-
-	// Delete removes all key-value pairs from the tree with
-	// a matching key
-	Delete(Key) error
-
-	// getFillState checks if the node's key slice is empty,
-	// partially full, or completely full.
-	getFillState() fillState
-}
-
-// internalNode stores pointers to other nodes in a B+ tree.
-type internalNode struct {
-	// Max number of keys in the node
-	order int
-
-	// Slice of keys in the node. The values less than the
-	// i-th key will be in the i-th pointer. Values greater
-	// than the i-th key (but less than the i+1-th key) will
-	// be in the i+1th pointer.
-	keys []Key
-
-	// Pointers to child nodes. Pointer i points to the
-	// node to the left of Key i.
-	//
-	//   len(n.pointers) == len(n.keys) + 1
-	//
-	// There will be one more pointer than key as, for
-	// n keys, the n+1th pointer will point to the node
-	// to the right of the nth key.
-	//
-	pointers []node
-}
-
-// newInternalNode creates a new internalNode for a B+ tree
-// with the specified order. The node can hold a maximum of
-// `order` nodes.
-//
-// For node n:
+// Insert adds a new value to the tree, at the given key. If the
+// insert causes the root to split, a new root is created above
+// the old root and its new sibling. If the tree was constructed
+// with allowDuplicates false, Insert overwrites the value of an
+// existing matching key rather than adding a second entry.
 //
-//   len(n.keys) == n.order
-//
-// and:
-//
-//   len(n.pointers) == n.order + 1
-//
-func newInternalNode(order int) *internalNode {
-	return &internalNode{
-		order:    order,
-		keys:     make([]Key, order),
-		pointers: make([]node, order+1),
+// If a Snapshot or Clone of the tree is currently open, Insert
+// copies rather than mutates any node still reachable from it;
+// see Snapshot for the isolation this provides.
+func (t *BPlusTree[K, V]) Insert(k K, v V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	gen := t.snapGen
+	newRoot, promoted, sibling, err := t.root.Insert(gen, k, v)
+	if err != nil {
+		return err
 	}
-}
-
-func (n *internalNode) getFillState() fillState {
-	for i, k := range n.keys {
-		if k != nil {
-			continue
-		}
-		if i == 0 {
-			return nodeEmpty
-		}
-		return nodePartiallyFull
+	t.root = newRoot
+	if sibling == nil {
+		return nil
 	}
-	return nodeFull
-}
 
-func (n *internalNode) GetKeys() ([]Key, error) {
-	var keys []Key
-
-	// For each child node...
-	for _, p := range n.pointers {
-		// Is it empty?
-		if p == nil {
-			break
-		}
-
-		// Get child node's keys...
-		k, err := p.GetKeys()
-		if err != nil {
-			return nil, err
-		}
-
-		// Add them to the slice...
-		keys = append(keys, k...)
+	parent, err := newInternalNode[K, V](t.order, t.less, t.allowDuplicates, gen, t.store, t.keyCodec)
+	if err != nil {
+		return err
 	}
-	return keys, nil
-}
-
-func (n *internalNode) GetValues() ([]Value, error) {
-	var vals []Value
-
-	// For each child node...
-	for _, p := range n.pointers {
-		// Is it empty?
-		if p == nil {
-			break
-		}
-
-		// Get child node's values...
-		v, err := p.GetValues()
-		if err != nil {
-			return nil, err
-		}
+	parent.keys = append(parent.keys, *promoted)
+	parent.pointers = append(parent.pointers, newRoot, sibling)
+	t.root = parent
 
-		// Add them to the slice...
-		vals = append(vals, v...)
+	if err := parent.persistSelf(); err != nil {
+		return err
 	}
-	return vals, nil
+	return t.persistRoot()
 }
 
-func (n *internalNode) Search(k Key) (Value, error) {
-	return nil, nil
-}
-
-func (n *internalNode) SearchRange(k1, k2 Key) ([]Value, error) {
-	return nil, nil
-}
-
-func (n *internalNode) Insert(k Key, v Value) error {
-	return nil
-}
-
-func (n *internalNode) Update(k Key, v Value) error {
-	return nil
+// InsertMulti adds v under k without overwriting any existing
+// entry for k, appending to the end of any existing run of
+// equal keys. It requires a tree constructed with
+// allowDuplicates true; on a unique-key tree it returns an
+// error instead of violating that invariant.
+func (t *BPlusTree[K, V]) InsertMulti(k K, v V) error {
+	if !t.allowDuplicates {
+		return fmt.Errorf("bplus: InsertMulti requires a tree constructed with allowDuplicates")
+	}
+	return t.Insert(k, v)
 }
 
-func (n *internalNode) Delete(k Key) error {
-	return nil
+// lowerBoundLeaf returns the leaf and within-leaf index of the
+// true first occurrence of k, correcting for a run of duplicate
+// keys that a leaf split left spanning two (or more) leaves: if
+// allowDuplicates split a full run of k across leaf boundaries,
+// the leading leaves in the run are entirely filled with k, so
+// walking backward while the previous leaf's last key is still
+// k finds the run's actual start.
+func (t *BPlusTree[K, V]) lowerBoundLeaf(k K) (*leafNode[K, V], int) {
+	return lowerBoundLeaf(t.root, t.less, k)
 }
 
-// leafNode stores the data for a B+ tree leaf node.
-type leafNode struct {
-	order    int     // Max number of keys in the node
-	keys     []Key   // Slice of keys contained in the node
-	pointers []Value // "Pointers" to the data mapped to each corresponding key
-	next     node    // A pointer to the next leaf node (if any)
+// upperBoundLeaf returns the leaf and within-leaf index of the
+// true first key greater than k, with the same duplicate-run
+// correction as lowerBoundLeaf but walking forward.
+func (t *BPlusTree[K, V]) upperBoundLeaf(k K) (*leafNode[K, V], int) {
+	return upperBoundLeaf(t.root, t.less, k)
 }
 
-// newLeafNode creates a new leaf node with the specified order.
-func newLeafNode(order int) *leafNode {
-	return &leafNode{
-		order:    order,
-		keys:     make([]Key, order),
-		pointers: make([]Value, order),
-	}
-}
-
-func (n *leafNode) getFillState() fillState {
-	for i, k := range n.keys {
-		if k != nil {
-			continue
+// lowerBoundLeaf is the free-function form of
+// (*BPlusTree).lowerBoundLeaf, taking root and less explicitly
+// so a Snapshot can share the same duplicate-run correction
+// without depending on a live BPlusTree.
+func lowerBoundLeaf[K, V any](root node[K, V], less func(a, b K) bool, k K) (*leafNode[K, V], int) {
+	leaf, _ := root.lowerBound(k)
+	for {
+		prev, ok := leaf.prev.(*leafNode[K, V])
+		if !ok || len(prev.keys) == 0 {
+			break
 		}
-		if i == 0 {
-			return nodeEmpty
+		last := prev.keys[len(prev.keys)-1]
+		if less(last, k) || less(k, last) {
+			break
 		}
-		return nodePartiallyFull
+		leaf = prev
 	}
-	return nodeFull
+	_, idx := leaf.lowerBound(k)
+	return leaf, idx
 }
 
-func (n *leafNode) GetKeys() ([]Key, error) {
-	var keys []Key
-	for _, k := range n.keys {
-		if k == nil {
+// upperBoundLeaf is the free-function form of
+// (*BPlusTree).upperBoundLeaf; see lowerBoundLeaf.
+func upperBoundLeaf[K, V any](root node[K, V], less func(a, b K) bool, k K) (*leafNode[K, V], int) {
+	leaf, _ := root.upperBound(k)
+	for len(leaf.keys) > 0 {
+		last := leaf.keys[len(leaf.keys)-1]
+		if less(last, k) || less(k, last) {
+			break
+		}
+		next, ok := leaf.next.(*leafNode[K, V])
+		if !ok || len(next.keys) == 0 {
 			break
 		}
-		keys = append(keys, k)
+		leaf = next
 	}
-	return keys, nil
+	_, idx := leaf.upperBound(k)
+	return leaf, idx
 }
 
-func (n *leafNode) GetValues() ([]Value, error) {
-	var vals []Value
-	for _, v := range n.pointers {
-		if v == nil {
+// SearchAll returns every value stored under k, in insertion
+// order, by walking forward along the leaf chain from k's first
+// occurrence until the keys diverge.
+func (t *BPlusTree[K, V]) SearchAll(k K) ([]V, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cur, i := t.lowerBoundLeaf(k)
+	var out []V
+	for cur != nil {
+		if i >= len(cur.keys) {
+			next, _ := cur.next.(*leafNode[K, V])
+			cur, i = next, 0
+			continue
+		}
+		if t.less(k, cur.keys[i]) {
 			break
 		}
-		vals = append(vals, v)
+		out = append(out, cur.pointers[i])
+		i++
 	}
-	return vals, nil
+	return out, nil
 }
 
-func (n *leafNode) Search(k Key) (Value, error) {
-	return nil, nil
+// DeleteOne removes the first key-value pair matching both k
+// and v, comparing values with reflect.DeepEqual. It reports no
+// error if no such pair exists.
+//
+// Like Delete, DeleteOne copies rather than mutates a node still
+// reachable from an open Snapshot or Clone.
+func (t *BPlusTree[K, V]) DeleteOne(k K, v V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	newRoot, _, err := t.root.DeleteOne(t.snapGen, k, v, func(a, b V) bool { return reflect.DeepEqual(a, b) })
+	t.root = newRoot
+	return err
 }
 
-func (n *leafNode) SearchRange(k1, k2 Key) ([]Value, error) {
-	return nil, nil
+// DeleteAll removes every key-value pair with a matching key.
+// It is equivalent to Delete, and exists to make bag semantics
+// explicit at call sites that also use DeleteOne.
+func (t *BPlusTree[K, V]) DeleteAll(k K) error {
+	return t.Delete(k)
 }
 
-func (n *leafNode) Insert(k Key, v Value) error {
-	return nil
+// Update sets the value for all matching keys in the tree,
+// including every leaf a run of duplicate keys spans. If a
+// Snapshot or Clone of the tree is currently open, Update copies
+// rather than mutates any node still reachable from it.
+func (t *BPlusTree[K, V]) Update(k K, v V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	newRoot, err := t.root.Update(t.snapGen, k, v)
+	t.root = newRoot
+	return err
 }
 
-func (n *leafNode) Update(k Key, v Value) error {
-	return nil
+// Delete removes all key-value pairs from the tree with a
+// matching key, including every leaf a run of duplicate keys
+// spans.
+//
+// If a Snapshot or Clone of the tree is currently open, Delete
+// copies rather than mutates any node still reachable from it.
+func (t *BPlusTree[K, V]) Delete(k K) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	newRoot, err := t.root.Delete(t.snapGen, k)
+	t.root = newRoot
+	return err
 }
 
-func (n *leafNode) Delete(k Key) error {
-	return nil
+// node represents a node (either internal or leaf)
+// in the B+ tree.
+type node[K, V any] interface {
+	// GetKeys returns a slice of all keys in the tree
+	GetKeys() ([]K, error)
+
+	// GetValues returns a slice of all values in the tree
+	GetValues() ([]V, error)
+
+	// Search returns the first value with the matching
+	// key in the tree
+	Search(K) (V, error)
+
+	// SearchRange returns all values with a key in the
+	// inclusive range [k1, k2]
+	SearchRange(K, K) ([]V, error)
+
+	// Insert adds a new value to the tree, at the given key,
+	// under generation gen (see BPlusTree.snapGen). The first
+	// return is this node, or a copy-on-write clone of it if it
+	// was last owned by an earlier generation than gen; callers
+	// must replace their reference to the receiver with it. If
+	// inserting causes the node to split, the key promoted to the
+	// parent and the new right sibling are also returned;
+	// otherwise both are nil.
+	Insert(gen uint64, k K, v V) (node[K, V], *K, node[K, V], error)
+
+	// Update sets the value for all matching keys in the tree,
+	// returning this node or a copy-on-write clone of it in place
+	// of the receiver, as with Insert.
+	Update(gen uint64, k K, v V) (node[K, V], error)
+
+	// Delete removes all key-value pairs from the tree with a
+	// matching key, returning this node or a copy-on-write clone
+	// of it in place of the receiver, as with Insert.
+	Delete(gen uint64, k K) (node[K, V], error)
+
+	// DeleteOne removes the first key-value pair in the subtree
+	// rooted at this node whose key equals k and whose value
+	// matches v under eq, descending to the first leaf of k's
+	// run (the same one Delete would reach) and then forward
+	// along the next-chain for the rest of a duplicate-key run.
+	// It returns this node or a copy-on-write clone of it in
+	// place of the receiver, as with Insert, and whether a pair
+	// was removed.
+	DeleteOne(gen uint64, k K, v V, eq func(V, V) bool) (node[K, V], bool, error)
+
+	// getFillState checks if the node's key slice is empty,
+	// partially full, or completely full.
+	getFillState() fillState
+
+	// lowerBound returns the leaf and within-leaf index of the
+	// first key >= k, descending the subtree rooted at this
+	// node. The index may equal the leaf's key count if every
+	// key in the subtree is < k.
+	lowerBound(k K) (*leafNode[K, V], int)
+
+	// upperBound returns the leaf and within-leaf index of the
+	// first key > k, descending the subtree rooted at this
+	// node. The index may equal the leaf's key count if every
+	// key in the subtree is <= k.
+	upperBound(k K) (*leafNode[K, V], int)
+
+	// leftmostLeaf returns the leftmost leaf in the subtree
+	// rooted at this node.
+	leftmostLeaf() *leafNode[K, V]
+
+	// rightmostLeaf returns the rightmost leaf in the subtree
+	// rooted at this node.
+	rightmostLeaf() *leafNode[K, V]
 }
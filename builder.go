@@ -0,0 +1,197 @@
+package bplus
+
+import (
+	"fmt"
+)
+
+// defaultFillFactor is the fraction of a leaf's capacity that
+// Builder packs keys into, leaving room for later Inserts
+// without an immediate split.
+const defaultFillFactor = 0.7
+
+// Builder constructs a BPlusTree bottom-up from key-value pairs
+// added in sorted order, avoiding the repeated splits that n
+// individual Insert calls would incur. Create one with
+// NewBuilder, add pairs with Add, and call Finish to obtain the
+// completed tree.
+type Builder[K, V any] struct {
+	order           int
+	less            func(a, b K) bool
+	allowDuplicates bool
+	fillSize        int
+
+	leaves   []node[K, V]
+	curLeaf  *leafNode[K, V]
+	prevLeaf *leafNode[K, V]
+}
+
+// NewBuilder creates a Builder for a B+ tree of the given order,
+// ordering keys with less. allowDuplicates has the same meaning
+// as in NewBPlusTree: if false, Add overwrites the value of the
+// most recently added matching key instead of appending a
+// second entry for it.
+func NewBuilder[K, V any](order int, less func(a, b K) bool, allowDuplicates bool) *Builder[K, V] {
+	if order <= 0 {
+		panic("tree order must be >= 1")
+	}
+	if less == nil {
+		panic("less must not be nil")
+	}
+	fillSize := int(float64(order) * defaultFillFactor)
+	if fillSize < 1 {
+		fillSize = 1
+	}
+	return &Builder[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		fillSize:        fillSize,
+	}
+}
+
+// Add appends a key-value pair to the tree under construction.
+// Keys must be added in non-decreasing order according to the
+// Builder's less function, or Add returns an error.
+func (b *Builder[K, V]) Add(k K, v V) error {
+	if b.curLeaf == nil {
+		leaf, err := newLeafNode[K, V](b.order, b.less, b.allowDuplicates, 0, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		b.curLeaf = leaf
+	}
+
+	if n := len(b.curLeaf.keys); n > 0 {
+		last := b.curLeaf.keys[n-1]
+		if b.less(k, last) {
+			return fmt.Errorf("bplus: Builder.Add requires keys in non-decreasing order")
+		}
+		if !b.allowDuplicates && !b.less(last, k) {
+			b.curLeaf.pointers[n-1] = v
+			return nil
+		}
+	}
+
+	if len(b.curLeaf.keys) >= b.fillSize {
+		b.closeLeaf()
+		leaf, err := newLeafNode[K, V](b.order, b.less, b.allowDuplicates, 0, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		b.curLeaf = leaf
+	}
+
+	b.curLeaf.keys = append(b.curLeaf.keys, k)
+	b.curLeaf.pointers = append(b.curLeaf.pointers, v)
+	return nil
+}
+
+// closeLeaf appends the current leaf to the finished leaf list,
+// chaining it to the previously closed leaf.
+func (b *Builder[K, V]) closeLeaf() {
+	if b.prevLeaf != nil {
+		b.prevLeaf.next = b.curLeaf
+		b.curLeaf.prev = b.prevLeaf
+	}
+	b.leaves = append(b.leaves, b.curLeaf)
+	b.prevLeaf = b.curLeaf
+}
+
+// Finish builds the internal levels above the accumulated
+// leaves and returns the completed tree. The Builder must not
+// be reused after Finish is called.
+func (b *Builder[K, V]) Finish() (*BPlusTree[K, V], error) {
+	if b.curLeaf != nil {
+		b.closeLeaf()
+		b.curLeaf = nil
+	}
+
+	var root node[K, V]
+	if len(b.leaves) == 0 {
+		leaf, err := newLeafNode[K, V](b.order, b.less, b.allowDuplicates, 0, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		root = leaf
+	} else {
+		var err error
+		root, err = buildLevel[K, V](b.order, b.less, b.allowDuplicates, b.fillSize, b.leaves)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &BPlusTree[K, V]{
+		order:           b.order,
+		less:            b.less,
+		allowDuplicates: b.allowDuplicates,
+		root:            root,
+	}, nil
+}
+
+// buildLevel groups children into fillSize+1-sized internal
+// nodes and recurses over the resulting level until a single
+// root node remains.
+func buildLevel[K, V any](order int, less func(a, b K) bool, allowDuplicates bool, fillSize int, children []node[K, V]) (node[K, V], error) {
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	maxChildren := fillSize + 1
+	parents := make([]node[K, V], 0, (len(children)+maxChildren-1)/maxChildren)
+	for i := 0; i < len(children); i += maxChildren {
+		end := i + maxChildren
+		if end > len(children) {
+			end = len(children)
+		}
+		group := children[i:end]
+
+		parent, err := newInternalNode[K, V](order, less, allowDuplicates, 0, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		parent.pointers = append(parent.pointers, group...)
+		for _, c := range group[1:] {
+			parent.keys = append(parent.keys, firstKey(c))
+		}
+		parents = append(parents, parent)
+	}
+
+	return buildLevel[K, V](order, less, allowDuplicates, fillSize, parents)
+}
+
+// firstKey returns the smallest key in the subtree rooted at n.
+func firstKey[K, V any](n node[K, V]) K {
+	return n.leftmostLeaf().keys[0]
+}
+
+// Pairs is a push-style iterator over key-value pairs: it calls
+// yield once per pair, stopping early if yield returns false.
+// It has the same underlying shape as the standard library's
+// iter.Seq2[K, V] (so a Go 1.23+ caller can pass one with an
+// explicit Pairs[K, V](...) conversion), but BulkLoad drives it
+// with a plain call rather than Go's range-over-func syntax, so
+// this package does not require a Go 1.23 toolchain to build.
+type Pairs[K, V any] func(yield func(K, V) bool)
+
+// BulkLoad constructs a new in-memory B+ tree of the given
+// order from pairs, which must be supplied in non-decreasing
+// key order (as from a sorted snapshot or a merge/compaction
+// pass). It builds leaves and internal levels directly rather
+// than performing one Insert per pair, which is significantly
+// faster than warm-starting a tree with repeated Inserts.
+func BulkLoad[K, V any](order int, less func(a, b K) bool, allowDuplicates bool, pairs Pairs[K, V]) (*BPlusTree[K, V], error) {
+	b := NewBuilder[K, V](order, less, allowDuplicates)
+	var addErr error
+	pairs(func(k K, v V) bool {
+		if err := b.Add(k, v); err != nil {
+			addErr = err
+			return false
+		}
+		return true
+	})
+	if addErr != nil {
+		return nil, addErr
+	}
+	return b.Finish()
+}
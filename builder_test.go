@@ -0,0 +1,103 @@
+package bplus
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBuilderFinish checks that Builder produces a tree whose
+// Search results match the pairs added and whose GetKeys returns
+// one key per pair, across enough keys to force multiple leaves
+// and internal levels.
+func TestBuilderFinish(t *testing.T) {
+	const n = 200
+
+	b := NewBuilder[int, int](4, func(a, b int) bool { return a < b }, false)
+	for i := 0; i < n; i++ {
+		if err := b.Add(i, i*10); err != nil {
+			t.Fatalf("Add(%d): %v", i, err)
+		}
+	}
+	tree, err := b.Finish()
+	if err != nil {
+		t.Fatalf("Finish: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		v, err := tree.Search(i)
+		if err != nil {
+			t.Fatalf("Search(%d): %v", i, err)
+		}
+		if v != i*10 {
+			t.Errorf("Search(%d) = %d, want %d", i, v, i*10)
+		}
+	}
+
+	keys, err := tree.GetKeys()
+	if err != nil {
+		t.Fatalf("GetKeys: %v", err)
+	}
+	if len(keys) != n {
+		t.Fatalf("GetKeys returned %d keys, want %d", len(keys), n)
+	}
+}
+
+// TestBuilderAddOutOfOrder checks that Add rejects a key that
+// would violate the non-decreasing order it requires.
+func TestBuilderAddOutOfOrder(t *testing.T) {
+	b := NewBuilder[int, int](4, func(a, b int) bool { return a < b }, false)
+	if err := b.Add(2, 20); err != nil {
+		t.Fatalf("Add(2): %v", err)
+	}
+	if err := b.Add(1, 10); err == nil {
+		t.Fatal("Add(1) after Add(2) = nil error, want an error")
+	}
+}
+
+// TestBulkLoad checks that a tree built by BulkLoad returns the
+// pairs it was given: every value through Search for unique keys,
+// and every entry of the run through SearchAll for duplicate keys.
+func TestBulkLoad(t *testing.T) {
+	const n = 200
+
+	pairs := func(yield func(int, string) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(i, fmt.Sprintf("v%d", i)) {
+				return
+			}
+		}
+	}
+
+	tree, err := BulkLoad[int, string](4, func(a, b int) bool { return a < b }, false, pairs)
+	if err != nil {
+		t.Fatalf("BulkLoad: %v", err)
+	}
+	for i := 0; i < n; i++ {
+		v, err := tree.Search(i)
+		if err != nil {
+			t.Fatalf("Search(%d): %v", i, err)
+		}
+		if v != fmt.Sprintf("v%d", i) {
+			t.Errorf("Search(%d) = %q, want %q", i, v, fmt.Sprintf("v%d", i))
+		}
+	}
+
+	dupPairs := func(yield func(int, string) bool) {
+		for i := 0; i < n; i++ {
+			if !yield(5, fmt.Sprintf("d%d", i)) {
+				return
+			}
+		}
+	}
+	dupTree, err := BulkLoad[int, string](4, func(a, b int) bool { return a < b }, true, dupPairs)
+	if err != nil {
+		t.Fatalf("BulkLoad (duplicates): %v", err)
+	}
+	all, err := dupTree.SearchAll(5)
+	if err != nil {
+		t.Fatalf("SearchAll: %v", err)
+	}
+	if len(all) != n {
+		t.Fatalf("SearchAll returned %d entries, want %d", len(all), n)
+	}
+}
@@ -0,0 +1,79 @@
+// Package bytes provides the original []byte-keyed B+ tree API
+// on top of the generic bplus.BPlusTree, for callers upgrading
+// from a pre-generics version of the package without having to
+// rewrite their key/value encoding.
+package bytes
+
+import (
+	stdbytes "bytes"
+
+	"github.com/a-poor/bplus"
+)
+
+type (
+	Key   []byte // A byte-slice representing a key in a B+ tree
+	Value []byte // A byte-slice representing a leaf-node key's value in a B+ tree
+)
+
+// less orders two Keys using a byte-wise comparison.
+func less(a, b Key) bool {
+	return stdbytes.Compare(a, b) < 0
+}
+
+// BPlusTree is a []byte-keyed B+ tree, equivalent to the
+// pre-generics bplus.BPlusTree.
+type BPlusTree struct {
+	inner *bplus.BPlusTree[Key, Value]
+}
+
+// NewBPlusTree creates a new in-memory B+ tree with the
+// specified order. Repeated Insert calls for the same key add
+// additional entries rather than overwriting the existing one;
+// see bplus.NewBPlusTree's allowDuplicates for what that implies.
+func NewBPlusTree(order int) *BPlusTree {
+	return &BPlusTree{inner: bplus.NewBPlusTree[Key, Value](order, less, true)}
+}
+
+// Order returns the B+ tree's order (the max number of keys
+// allowes per node).
+func (t *BPlusTree) Order() int {
+	return t.inner.Order()
+}
+
+// GetKeys returns a slice of all keys in the tree
+func (t *BPlusTree) GetKeys() ([]Key, error) {
+	return t.inner.GetKeys()
+}
+
+// GetValues returns a slice of all values in the tree
+func (t *BPlusTree) GetValues() ([]Value, error) {
+	return t.inner.GetValues()
+}
+
+// Search returns the first value with the matching
+// key in the tree
+func (t *BPlusTree) Search(k Key) (Value, error) {
+	return t.inner.Search(k)
+}
+
+// SearchRange returns all values with a key in the inclusive
+// range [k1, k2]
+func (t *BPlusTree) SearchRange(k1, k2 Key) ([]Value, error) {
+	return t.inner.SearchRange(k1, k2)
+}
+
+// Insert adds a new value to the tree, at the given key
+func (t *BPlusTree) Insert(k Key, v Value) error {
+	return t.inner.Insert(k, v)
+}
+
+// Update sets the value for all matching keys in the tree
+func (t *BPlusTree) Update(k Key, v Value) error {
+	return t.inner.Update(k, v)
+}
+
+// Delete removes all key-value pairs from the tree with
+// a matching key
+func (t *BPlusTree) Delete(k Key) error {
+	return t.inner.Delete(k)
+}
@@ -0,0 +1,40 @@
+package bytes
+
+import "github.com/a-poor/bplus"
+
+var (
+	keyCodec = bplus.Codec[Key]{
+		Encode: func(k Key) ([]byte, error) { return k, nil },
+		Decode: func(b []byte) (Key, error) { return Key(b), nil },
+	}
+	valueCodec = bplus.Codec[Value]{
+		Encode: func(v Value) ([]byte, error) { return v, nil },
+		Decode: func(b []byte) (Value, error) { return Value(b), nil },
+	}
+)
+
+// NewPersistentBPlusTree creates a new, empty []byte-keyed B+
+// tree backed by store.
+func NewPersistentBPlusTree(order int, store bplus.PageStore) (*BPlusTree, error) {
+	inner, err := bplus.NewPersistentBPlusTree[Key, Value](order, less, true, store, keyCodec, valueCodec)
+	if err != nil {
+		return nil, err
+	}
+	return &BPlusTree{inner: inner}, nil
+}
+
+// Open reconstructs a []byte-keyed B+ tree previously written
+// to store.
+func Open(store bplus.PageStore) (*BPlusTree, error) {
+	inner, err := bplus.Open[Key, Value](store, less, keyCodec, valueCodec)
+	if err != nil {
+		return nil, err
+	}
+	return &BPlusTree{inner: inner}, nil
+}
+
+// Sync flushes the tree's backing store, if any, to durable
+// storage.
+func (t *BPlusTree) Sync() error {
+	return t.inner.Sync()
+}
@@ -0,0 +1,162 @@
+package bplus
+
+// cursorSource supplies the root and ordering function a Cursor
+// walks, letting the same Cursor implementation serve both a
+// live BPlusTree and an immutable Snapshot. lock and unlock let
+// each Cursor access serialize against concurrent writers when
+// the source is a live tree; a Snapshot's nodes never change, so
+// its lock/unlock are no-ops.
+type cursorSource[K, V any] interface {
+	cursorRoot() node[K, V]
+	cursorLess() func(K, K) bool
+	lock()
+	unlock()
+}
+
+// Cursor iterates over a source's key-value pairs in sorted
+// order, walking the leaf `next`/`prev` chain rather than
+// re-descending the tree for each step. A Cursor is not safe
+// for concurrent use. Each Cursor method call is individually
+// synchronized against concurrent writers when its source is a
+// live BPlusTree, but the cursor's position can still be left
+// pointing at a leaf a concurrent Delete has emptied and dropped
+// from the tree between calls; a Cursor over a Snapshot has no
+// such restriction, since the snapshot's nodes never change.
+type Cursor[K, V any] struct {
+	src  cursorSource[K, V]
+	leaf *leafNode[K, V]
+	idx  int
+}
+
+// Cursor returns a new Cursor over t, positioned before the
+// first key-value pair. Call Seek, SeekFirst, or SeekLast
+// before Next or Prev to establish a starting position.
+func (t *BPlusTree[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{src: t}
+}
+
+func (t *BPlusTree[K, V]) cursorRoot() node[K, V]      { return t.root }
+func (t *BPlusTree[K, V]) cursorLess() func(K, K) bool { return t.less }
+func (t *BPlusTree[K, V]) lock()                       { t.mu.Lock() }
+func (t *BPlusTree[K, V]) unlock()                     { t.mu.Unlock() }
+
+// Seek positions the cursor so that Next returns the first
+// key >= k, reporting whether that key is an exact match for
+// k.
+func (c *Cursor[K, V]) Seek(k K) bool {
+	c.src.lock()
+	defer c.src.unlock()
+
+	leaf, idx := lowerBoundLeaf(c.src.cursorRoot(), c.src.cursorLess(), k)
+	c.leaf, c.idx = leaf, idx
+	less := c.src.cursorLess()
+	return idx < len(leaf.keys) && !less(leaf.keys[idx], k) && !less(k, leaf.keys[idx])
+}
+
+// SeekFirst positions the cursor so that Next returns the
+// first key-value pair in the tree.
+func (c *Cursor[K, V]) SeekFirst() {
+	c.src.lock()
+	defer c.src.unlock()
+
+	c.leaf = c.src.cursorRoot().leftmostLeaf()
+	c.idx = 0
+}
+
+// SeekLast positions the cursor so that Prev returns the last
+// key-value pair in the tree.
+func (c *Cursor[K, V]) SeekLast() {
+	c.src.lock()
+	defer c.src.unlock()
+
+	c.leaf = c.src.cursorRoot().rightmostLeaf()
+	c.idx = len(c.leaf.keys)
+}
+
+// Next returns the key-value pair at the cursor's position and
+// advances the cursor forward. The final return is false once
+// the cursor has passed the last pair.
+func (c *Cursor[K, V]) Next() (K, V, bool) {
+	c.src.lock()
+	defer c.src.unlock()
+
+	var zeroK K
+	var zeroV V
+	for c.leaf != nil && c.idx >= len(c.leaf.keys) {
+		c.leaf, _ = c.leaf.next.(*leafNode[K, V])
+		c.idx = 0
+	}
+	if c.leaf == nil {
+		return zeroK, zeroV, false
+	}
+	k, v := c.leaf.keys[c.idx], c.leaf.pointers[c.idx]
+	c.idx++
+	return k, v, true
+}
+
+// Prev returns the key-value pair immediately before the
+// cursor's position and moves the cursor backward. The final
+// return is false once the cursor has passed the first pair.
+func (c *Cursor[K, V]) Prev() (K, V, bool) {
+	c.src.lock()
+	defer c.src.unlock()
+
+	var zeroK K
+	var zeroV V
+	for c.leaf != nil && c.idx <= 0 {
+		c.leaf, _ = c.leaf.prev.(*leafNode[K, V])
+		if c.leaf != nil {
+			c.idx = len(c.leaf.keys)
+		}
+	}
+	if c.leaf == nil {
+		return zeroK, zeroV, false
+	}
+	c.idx--
+	return c.leaf.keys[c.idx], c.leaf.pointers[c.idx], true
+}
+
+// Close releases the cursor's reference to its source. A closed
+// cursor must not be used again.
+func (c *Cursor[K, V]) Close() {
+	c.src = nil
+	c.leaf = nil
+}
+
+// Ascend calls fn for every key-value pair with a key in the
+// inclusive range [from, to], in ascending order, stopping
+// early if fn returns false. Unlike SearchRange, Ascend streams
+// pairs without materializing a slice, so it is suitable for
+// large ranges.
+func (t *BPlusTree[K, V]) Ascend(from, to K, fn func(K, V) bool) error {
+	c := t.Cursor()
+	c.Seek(from)
+	for {
+		k, v, ok := c.Next()
+		if !ok || t.less(to, k) {
+			return nil
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+}
+
+// Descend calls fn for every key-value pair with a key in the
+// inclusive range [from, to], in descending order, stopping
+// early if fn returns false.
+func (t *BPlusTree[K, V]) Descend(from, to K, fn func(K, V) bool) error {
+	c := t.Cursor()
+	t.mu.Lock()
+	c.leaf, c.idx = t.upperBoundLeaf(to)
+	t.mu.Unlock()
+	for {
+		k, v, ok := c.Prev()
+		if !ok || t.less(k, from) {
+			return nil
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+}
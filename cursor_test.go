@@ -0,0 +1,126 @@
+package bplus
+
+import "testing"
+
+func newCursorTestTree(t *testing.T, n int) *BPlusTree[int, int] {
+	t.Helper()
+	tree := NewBPlusTree[int, int](4, func(a, b int) bool { return a < b }, false)
+	for i := 0; i < n; i++ {
+		if err := tree.Insert(i, i*10); err != nil {
+			t.Fatalf("Insert(%d): %v", i, err)
+		}
+	}
+	return tree
+}
+
+// TestCursorSeekFirstNext walks the whole tree forward from
+// SeekFirst and checks every pair comes back in order.
+func TestCursorSeekFirstNext(t *testing.T) {
+	const n = 50
+	tree := newCursorTestTree(t, n)
+
+	c := tree.Cursor()
+	c.SeekFirst()
+	for i := 0; i < n; i++ {
+		k, v, ok := c.Next()
+		if !ok {
+			t.Fatalf("Next() ran out at i=%d, want %d pairs", i, n)
+		}
+		if k != i || v != i*10 {
+			t.Fatalf("Next() = (%d, %d), want (%d, %d)", k, v, i, i*10)
+		}
+	}
+	if _, _, ok := c.Next(); ok {
+		t.Fatal("Next() returned a pair past the end of the tree")
+	}
+}
+
+// TestCursorSeekLastPrev walks the whole tree backward from
+// SeekLast and checks every pair comes back in reverse order.
+func TestCursorSeekLastPrev(t *testing.T) {
+	const n = 50
+	tree := newCursorTestTree(t, n)
+
+	c := tree.Cursor()
+	c.SeekLast()
+	for i := n - 1; i >= 0; i-- {
+		k, v, ok := c.Prev()
+		if !ok {
+			t.Fatalf("Prev() ran out at i=%d, want %d pairs", i, n)
+		}
+		if k != i || v != i*10 {
+			t.Fatalf("Prev() = (%d, %d), want (%d, %d)", k, v, i, i*10)
+		}
+	}
+	if _, _, ok := c.Prev(); ok {
+		t.Fatal("Prev() returned a pair past the start of the tree")
+	}
+}
+
+// TestCursorSeek checks Seek's exact-match report and that Next
+// from a Seek position returns the rest of the tree in order.
+func TestCursorSeek(t *testing.T) {
+	const n = 50
+	tree := newCursorTestTree(t, n)
+
+	c := tree.Cursor()
+	if !c.Seek(10) {
+		t.Fatal("Seek(10) = false, want true for an exact match")
+	}
+	if c.Seek(1000) {
+		t.Fatal("Seek(1000) = true, want false past the end of the tree")
+	}
+	if c.Seek(10000) {
+		t.Fatal("Seek(10000) = true, want false past the end of the tree")
+	}
+
+	c.Seek(10)
+	for i := 10; i < n; i++ {
+		k, v, ok := c.Next()
+		if !ok || k != i || v != i*10 {
+			t.Fatalf("Next() after Seek(10) = (%d, %d, %v), want (%d, %d, true)", k, v, ok, i, i*10)
+		}
+	}
+}
+
+// TestCursorAscendDescend checks that Ascend and Descend visit
+// exactly the keys in the requested range, in the expected order.
+func TestCursorAscendDescend(t *testing.T) {
+	tree := newCursorTestTree(t, 50)
+
+	var ascended []int
+	if err := tree.Ascend(10, 15, func(k, v int) bool {
+		ascended = append(ascended, k)
+		return true
+	}); err != nil {
+		t.Fatalf("Ascend: %v", err)
+	}
+	wantAscend := []int{10, 11, 12, 13, 14, 15}
+	if !equalInts(ascended, wantAscend) {
+		t.Errorf("Ascend(10, 15) visited %v, want %v", ascended, wantAscend)
+	}
+
+	var descended []int
+	if err := tree.Descend(10, 15, func(k, v int) bool {
+		descended = append(descended, k)
+		return true
+	}); err != nil {
+		t.Fatalf("Descend: %v", err)
+	}
+	wantDescend := []int{15, 14, 13, 12, 11, 10}
+	if !equalInts(descended, wantDescend) {
+		t.Errorf("Descend(10, 15) visited %v, want %v", descended, wantDescend)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
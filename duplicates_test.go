@@ -0,0 +1,88 @@
+package bplus
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestDuplicateRunSpanningLeaves inserts far more than 2*order
+// duplicates of a single key so the run is guaranteed to span
+// several leaves (and, with this small an order, several levels
+// of internal nodes too), then checks that SearchRange, Update,
+// and DeleteOne/Delete all see the whole run rather than just the
+// last leaf internalNode.childIndex's rightmost-landing descent
+// would reach.
+func TestDuplicateRunSpanningLeaves(t *testing.T) {
+	const order = 2
+	const n = 40
+
+	tree := NewBPlusTree[int, string](order, func(a, b int) bool { return a < b }, true)
+	for i := 0; i < n; i++ {
+		if err := tree.InsertMulti(5, valueFor(i)); err != nil {
+			t.Fatalf("InsertMulti(%d): %v", i, err)
+		}
+	}
+
+	all, err := tree.SearchAll(5)
+	if err != nil {
+		t.Fatalf("SearchAll: %v", err)
+	}
+	if len(all) != n {
+		t.Fatalf("SearchAll returned %d entries, want %d", len(all), n)
+	}
+
+	rng, err := tree.SearchRange(5, 5)
+	if err != nil {
+		t.Fatalf("SearchRange: %v", err)
+	}
+	if len(rng) != n {
+		t.Fatalf("SearchRange returned %d entries, want %d", len(rng), n)
+	}
+
+	if err := tree.DeleteOne(5, valueFor(3)); err != nil {
+		t.Fatalf("DeleteOne: %v", err)
+	}
+	all, err = tree.SearchAll(5)
+	if err != nil {
+		t.Fatalf("SearchAll after DeleteOne: %v", err)
+	}
+	if len(all) != n-1 {
+		t.Fatalf("SearchAll after DeleteOne returned %d entries, want %d", len(all), n-1)
+	}
+	for _, v := range all {
+		if v == valueFor(3) {
+			t.Fatalf("DeleteOne left %q in the tree", valueFor(3))
+		}
+	}
+
+	if err := tree.Update(5, "updated"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	all, err = tree.SearchAll(5)
+	if err != nil {
+		t.Fatalf("SearchAll after Update: %v", err)
+	}
+	if len(all) != n-1 {
+		t.Fatalf("SearchAll after Update returned %d entries, want %d", len(all), n-1)
+	}
+	for _, v := range all {
+		if v != "updated" {
+			t.Errorf("SearchAll after Update returned %q, want %q", v, "updated")
+		}
+	}
+
+	if err := tree.Delete(5); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	all, err = tree.SearchAll(5)
+	if err != nil {
+		t.Fatalf("SearchAll after Delete: %v", err)
+	}
+	if len(all) != 0 {
+		t.Fatalf("SearchAll after Delete returned %d entries, want 0", len(all))
+	}
+}
+
+func valueFor(i int) string {
+	return fmt.Sprintf("v%d", i)
+}
@@ -0,0 +1,363 @@
+package bplus
+
+// internalNode stores pointers to other nodes in a B+ tree.
+type internalNode[K, V any] struct {
+	// Max number of keys in the node
+	order int
+
+	// less reports whether a sorts before b, used to order
+	// keys and locate child pointers.
+	less func(a, b K) bool
+
+	// allowDuplicates reports whether multiple entries may
+	// share a key; propagated to any leaf or internal children
+	// created as this node splits.
+	allowDuplicates bool
+
+	// Keys in the node, len(keys) <= order. The values less
+	// than the i-th key will be in the i-th pointer. Values
+	// greater than or equal to the i-th key (but less than the
+	// i+1-th key) will be in the i+1-th pointer.
+	keys []K
+
+	// Pointers to child nodes. Pointer i points to the
+	// node to the left of Key i.
+	//
+	//   len(n.pointers) == len(n.keys) + 1
+	//
+	// There will be one more pointer than key as, for
+	// n keys, the n+1th pointer will point to the node
+	// to the right of the nth key.
+	//
+	pointers []node[K, V]
+
+	// store is the backing page store for this node, or nil
+	// if the node only lives in memory.
+	store PageStore
+
+	// keyCodec encodes/decodes keys for store, nil when store
+	// is nil.
+	keyCodec *Codec[K]
+
+	// pageID is this node's page in store, valid when store
+	// is non-nil.
+	pageID pageID
+
+	// dirty marks that this node has changes not yet written
+	// to store.
+	dirty bool
+
+	// ownerGen is the generation (BPlusTree.snapGen as captured
+	// by the Insert/Update/Delete/DeleteOne call that created or
+	// last cloned this node) that may mutate it in place. A node
+	// from an earlier generation is reachable from a still-open
+	// Snapshot or Clone and must be copied first; see
+	// ensureOwned.
+	ownerGen uint64
+}
+
+// newInternalNode creates a new internalNode for a B+ tree
+// with the specified order, owned by generation gen. The node
+// can hold a maximum of `order` keys and `order`+1 child
+// pointers.
+//
+// If store is non-nil, a page is allocated for the node
+// immediately so it can be referenced by a parent before it
+// is first persisted.
+func newInternalNode[K, V any](order int, less func(a, b K) bool, allowDuplicates bool, gen uint64, store PageStore, keyCodec *Codec[K]) (*internalNode[K, V], error) {
+	n := &internalNode[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		keys:            make([]K, 0, order),
+		pointers:        make([]node[K, V], 0, order+1),
+		store:           store,
+		keyCodec:        keyCodec,
+		ownerGen:        gen,
+	}
+	if store != nil {
+		id, err := store.Allocate()
+		if err != nil {
+			return nil, err
+		}
+		n.pageID = id
+		n.dirty = true
+	}
+	return n, nil
+}
+
+// ensureOwned returns n if it is already owned by generation
+// gen, or otherwise a clone of n, owned by gen, that is safe to
+// mutate in place. The clone's pointers slice is copied, but the
+// child nodes it points to are not; callers that descend into a
+// child must replace it with whatever that child's own Insert,
+// Update, or Delete returns.
+func (n *internalNode[K, V]) ensureOwned(gen uint64) *internalNode[K, V] {
+	if n.ownerGen >= gen {
+		return n
+	}
+	clone := *n
+	clone.ownerGen = gen
+	clone.keys = append([]K(nil), n.keys...)
+	clone.pointers = append([]node[K, V](nil), n.pointers...)
+	return &clone
+}
+
+func (n *internalNode[K, V]) getFillState() fillState {
+	switch {
+	case len(n.keys) == 0:
+		return nodeEmpty
+	case len(n.keys) >= n.order:
+		return nodeFull
+	default:
+		return nodePartiallyFull
+	}
+}
+
+// childIndex returns the index of the child pointer that k
+// should be searched for, or inserted under.
+func (n *internalNode[K, V]) childIndex(k K) int {
+	idx := 0
+	for idx < len(n.keys) && !n.less(k, n.keys[idx]) {
+		idx++
+	}
+	return idx
+}
+
+// firstChildIndex is like childIndex, but corrects for a run of
+// separator keys all equal to k. A long duplicate-key run can
+// leave several adjacent separators equal to k behind (see the
+// allowDuplicates split adjustment in leafNode.Insert), and
+// childIndex lands on the rightmost of the children they
+// separate. That is what Insert wants, since InsertMulti appends
+// to the end of a run, but an operation that must reach every
+// child holding a piece of the run -- not just its last leaf --
+// needs the leftmost of them instead, so its forward walk covers
+// the whole run rather than starting partway through it. It only
+// steps left past a separator equal to k while the child on the
+// separator's left still ends in k, so a child whose keys are all
+// less than k is never chosen.
+func (n *internalNode[K, V]) firstChildIndex(k K) int {
+	idx := n.childIndex(k)
+	for idx > 0 && !n.less(n.keys[idx-1], k) && !n.less(k, n.keys[idx-1]) {
+		left := n.pointers[idx-1].rightmostLeaf()
+		if len(left.keys) == 0 {
+			break
+		}
+		last := left.keys[len(left.keys)-1]
+		if n.less(last, k) || n.less(k, last) {
+			break
+		}
+		idx--
+	}
+	return idx
+}
+
+func (n *internalNode[K, V]) GetKeys() ([]K, error) {
+	var keys []K
+	for _, p := range n.pointers {
+		k, err := p.GetKeys()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k...)
+	}
+	return keys, nil
+}
+
+func (n *internalNode[K, V]) GetValues() ([]V, error) {
+	var vals []V
+	for _, p := range n.pointers {
+		v, err := p.GetValues()
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v...)
+	}
+	return vals, nil
+}
+
+func (n *internalNode[K, V]) Search(k K) (V, error) {
+	return n.pointers[n.firstChildIndex(k)].Search(k)
+}
+
+func (n *internalNode[K, V]) SearchRange(k1, k2 K) ([]V, error) {
+	return n.pointers[n.firstChildIndex(k1)].SearchRange(k1, k2)
+}
+
+// lowerBound descends into the child responsible for k and
+// returns the leaf and index of the first key >= k.
+func (n *internalNode[K, V]) lowerBound(k K) (*leafNode[K, V], int) {
+	return n.pointers[n.childIndex(k)].lowerBound(k)
+}
+
+// upperBound descends into the child responsible for k and
+// returns the leaf and index of the first key > k.
+func (n *internalNode[K, V]) upperBound(k K) (*leafNode[K, V], int) {
+	return n.pointers[n.childIndex(k)].upperBound(k)
+}
+
+func (n *internalNode[K, V]) leftmostLeaf() *leafNode[K, V] {
+	return n.pointers[0].leftmostLeaf()
+}
+
+func (n *internalNode[K, V]) rightmostLeaf() *leafNode[K, V] {
+	return n.pointers[len(n.pointers)-1].rightmostLeaf()
+}
+
+// Insert descends into the child responsible for k. If that
+// child splits, the promoted key and new sibling are folded
+// into this node, splitting it in turn if it is already full.
+func (n *internalNode[K, V]) Insert(gen uint64, k K, v V) (node[K, V], *K, node[K, V], error) {
+	n = n.ensureOwned(gen)
+
+	idx := n.childIndex(k)
+	newChild, promoted, sibling, err := n.pointers[idx].Insert(gen, k, v)
+	n.pointers[idx] = newChild
+	if err != nil || sibling == nil {
+		return n, nil, nil, err
+	}
+
+	tmpKeys := make([]K, 0, len(n.keys)+1)
+	tmpKeys = append(tmpKeys, n.keys[:idx]...)
+	tmpKeys = append(tmpKeys, *promoted)
+	tmpKeys = append(tmpKeys, n.keys[idx:]...)
+
+	tmpPointers := make([]node[K, V], 0, len(n.pointers)+1)
+	tmpPointers = append(tmpPointers, n.pointers[:idx+1]...)
+	tmpPointers = append(tmpPointers, sibling)
+	tmpPointers = append(tmpPointers, n.pointers[idx+1:]...)
+
+	if len(tmpKeys) <= n.order {
+		n.keys = append(n.keys[:0], tmpKeys...)
+		n.pointers = append(n.pointers[:0], tmpPointers...)
+		return n, nil, nil, n.persistSelf()
+	}
+
+	// n is full: split it, promoting the middle key up to our
+	// caller rather than keeping it in either half.
+	mid := len(tmpKeys) / 2
+	promotedUp := tmpKeys[mid]
+
+	right, err := newInternalNode[K, V](n.order, n.less, n.allowDuplicates, gen, n.store, n.keyCodec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	right.keys = append(right.keys, tmpKeys[mid+1:]...)
+	right.pointers = append(right.pointers, tmpPointers[mid+1:]...)
+
+	n.keys = append(n.keys[:0], tmpKeys[:mid]...)
+	n.pointers = append(n.pointers[:0], tmpPointers[:mid+1]...)
+
+	// The new sibling must be durable before the parent learns
+	// about it, and this node is persisted before it is handed
+	// back to the caller.
+	if err := right.persistSelf(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := n.persistSelf(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return n, &promotedUp, right, nil
+}
+
+// Update descends into the child responsible for the first
+// occurrence of k, replacing it with whatever that child's own
+// Update returns, as with Insert. Using firstChildIndex rather
+// than childIndex matters here: a run of equal keys split across
+// several leaves needs its forward walk (see leafNode.Update) to
+// start at the first of them, not the last.
+func (n *internalNode[K, V]) Update(gen uint64, k K, v V) (node[K, V], error) {
+	n = n.ensureOwned(gen)
+	idx := n.firstChildIndex(k)
+	newChild, err := n.pointers[idx].Update(gen, k, v)
+	n.pointers[idx] = newChild
+	return n, err
+}
+
+// Delete descends into the child responsible for the first
+// occurrence of k (see firstChildIndex), replacing it with
+// whatever that child's own Delete returns, as with Insert.
+//
+// If that leaves a leaf child entirely empty, and n has another
+// child to fall back on, the empty leaf is dropped from n and
+// its page freed; see dropEmptyLeafChild. No other rebalancing
+// is performed: an internal child left with too few keys, or a
+// leaf left underfull but non-empty, is kept as is rather than
+// merged with or redistributed from a sibling.
+func (n *internalNode[K, V]) Delete(gen uint64, k K) (node[K, V], error) {
+	n = n.ensureOwned(gen)
+	idx := n.firstChildIndex(k)
+	newChild, err := n.pointers[idx].Delete(gen, k)
+	if err != nil {
+		n.pointers[idx] = newChild
+		return n, err
+	}
+	if leaf, ok := newChild.(*leafNode[K, V]); ok && len(leaf.keys) == 0 && len(n.pointers) > 1 {
+		return n, n.dropEmptyLeafChild(idx, leaf)
+	}
+	n.pointers[idx] = newChild
+	return n, nil
+}
+
+// dropEmptyLeafChild removes the now-empty leaf at pointers[idx]
+// from n, unlinking it from the leaf chain and freeing its
+// backing page. It must only be called when n has more than one
+// child remaining, so removing one still leaves n with at least
+// one pointer.
+//
+// Unlike the rest of a Delete call, this re-threads leaf's
+// neighbors' next/prev fields in place rather than through
+// ensureOwned: prev and next are already reachable from the live
+// tree through their own parent's pointers, which this call has
+// no way to retarget at a copy, so there is no copy-on-write-safe
+// way to drop leaf from the chain. A Snapshot or Clone taken
+// before this Delete can therefore observe the chain link change
+// out from under it on the rare path where a Delete empties a
+// leaf entirely; every other mutation in this package preserves
+// Snapshot isolation, so this is a known, narrow exception rather
+// than the general rule.
+func (n *internalNode[K, V]) dropEmptyLeafChild(idx int, leaf *leafNode[K, V]) error {
+	if prev, ok := leaf.prev.(*leafNode[K, V]); ok {
+		prev.next = leaf.next
+		if err := prev.persistSelf(); err != nil {
+			return err
+		}
+	}
+	if next, ok := leaf.next.(*leafNode[K, V]); ok {
+		next.prev = leaf.prev
+		if err := next.persistSelf(); err != nil {
+			return err
+		}
+	}
+
+	// keys[i] separates pointers[i] and pointers[i+1], so
+	// dropping pointers[idx] drops keys[idx-1] (the separator to
+	// its left), or keys[0] if it was the leftmost child.
+	keyIdx := idx
+	if keyIdx > 0 {
+		keyIdx--
+	}
+	n.keys = append(n.keys[:keyIdx], n.keys[keyIdx+1:]...)
+	n.pointers = append(n.pointers[:idx], n.pointers[idx+1:]...)
+
+	if err := n.persistSelf(); err != nil {
+		return err
+	}
+	if n.store == nil {
+		return nil
+	}
+	return n.store.Free(leaf.pageID)
+}
+
+// DeleteOne descends into the child responsible for the first
+// occurrence of k (see firstChildIndex), replacing it with
+// whatever that child's own DeleteOne returns, as with Insert.
+func (n *internalNode[K, V]) DeleteOne(gen uint64, k K, v V, eq func(V, V) bool) (node[K, V], bool, error) {
+	n = n.ensureOwned(gen)
+	idx := n.firstChildIndex(k)
+	newChild, removed, err := n.pointers[idx].DeleteOne(gen, k, v, eq)
+	n.pointers[idx] = newChild
+	return n, removed, err
+}
@@ -0,0 +1,422 @@
+package bplus
+
+// leafNode stores the data for a B+ tree leaf node.
+type leafNode[K, V any] struct {
+	order int // Max number of keys in the node
+
+	// less reports whether a sorts before b, used to order
+	// keys within the node.
+	less func(a, b K) bool
+
+	// allowDuplicates reports whether multiple entries may
+	// share a key. When false, Insert overwrites the existing
+	// entry for a matching key instead of adding a new one.
+	allowDuplicates bool
+
+	keys     []K        // Keys contained in the node, len(keys) <= order
+	pointers []V        // Values mapped to each corresponding key
+	next     node[K, V] // A pointer to the next leaf node (if any)
+	prev     node[K, V] // A pointer to the previous leaf node (if any)
+
+	// store is the backing page store for this node, or nil
+	// if the node only lives in memory.
+	store PageStore
+
+	// keyCodec and valueCodec encode/decode keys and values
+	// for store; both are nil when store is nil.
+	keyCodec   *Codec[K]
+	valueCodec *Codec[V]
+
+	// pageID is this node's page in store, valid when store
+	// is non-nil.
+	pageID pageID
+
+	// dirty marks that this node has changes not yet written
+	// to store.
+	dirty bool
+
+	// ownerGen is the generation (BPlusTree.snapGen as captured
+	// by the Insert/Update/Delete/DeleteOne call that created or
+	// last cloned this node) that may mutate it in place. A node
+	// from an earlier generation is reachable from a still-open
+	// Snapshot or Clone and must be copied first; see
+	// ensureOwned.
+	ownerGen uint64
+}
+
+// newLeafNode creates a new leaf node with the specified order,
+// owned by generation gen. If store is non-nil, a page is
+// allocated for the node immediately so it can be referenced by
+// a parent before it is first persisted.
+func newLeafNode[K, V any](order int, less func(a, b K) bool, allowDuplicates bool, gen uint64, store PageStore, keyCodec *Codec[K], valueCodec *Codec[V]) (*leafNode[K, V], error) {
+	n := &leafNode[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		keys:            make([]K, 0, order),
+		pointers:        make([]V, 0, order),
+		store:           store,
+		keyCodec:        keyCodec,
+		valueCodec:      valueCodec,
+		ownerGen:        gen,
+	}
+	if store != nil {
+		id, err := store.Allocate()
+		if err != nil {
+			return nil, err
+		}
+		n.pageID = id
+		n.dirty = true
+	}
+	return n, nil
+}
+
+// ensureOwned returns n if it is already owned by generation
+// gen, or otherwise a clone of n, owned by gen, that is safe to
+// mutate in place. The clone still shares its next/prev
+// neighbors with n; see the limitation documented on Delete for
+// what that means for an in-progress duplicate-key run.
+func (n *leafNode[K, V]) ensureOwned(gen uint64) *leafNode[K, V] {
+	if n.ownerGen >= gen {
+		return n
+	}
+	clone := *n
+	clone.ownerGen = gen
+	clone.keys = append([]K(nil), n.keys...)
+	clone.pointers = append([]V(nil), n.pointers...)
+	return &clone
+}
+
+func (n *leafNode[K, V]) getFillState() fillState {
+	switch {
+	case len(n.keys) == 0:
+		return nodeEmpty
+	case len(n.keys) >= n.order:
+		return nodeFull
+	default:
+		return nodePartiallyFull
+	}
+}
+
+func (n *leafNode[K, V]) GetKeys() ([]K, error) {
+	out := make([]K, len(n.keys))
+	copy(out, n.keys)
+	return out, nil
+}
+
+func (n *leafNode[K, V]) GetValues() ([]V, error) {
+	out := make([]V, len(n.pointers))
+	copy(out, n.pointers)
+	return out, nil
+}
+
+// Search returns the first value with the matching key in the
+// leaf.
+func (n *leafNode[K, V]) Search(k K) (V, error) {
+	var zero V
+	for i, key := range n.keys {
+		if !n.less(key, k) && !n.less(k, key) {
+			return n.pointers[i], nil
+		}
+	}
+	return zero, nil
+}
+
+// SearchRange returns all values with a key in the inclusive
+// range [k1, k2], walking forward along the next-leaf chain
+// until keys run past k2.
+func (n *leafNode[K, V]) SearchRange(k1, k2 K) ([]V, error) {
+	var result []V
+	cur := n
+	for cur != nil {
+		stop := false
+		for i, key := range cur.keys {
+			if cur.less(key, k1) {
+				continue
+			}
+			if cur.less(k2, key) {
+				stop = true
+				break
+			}
+			result = append(result, cur.pointers[i])
+		}
+		if stop {
+			break
+		}
+		next, _ := cur.next.(*leafNode[K, V])
+		cur = next
+	}
+	return result, nil
+}
+
+// lowerBound returns n and the index of the first key >= k,
+// or len(n.keys) if every key in n is less than k.
+func (n *leafNode[K, V]) lowerBound(k K) (*leafNode[K, V], int) {
+	idx := 0
+	for idx < len(n.keys) && n.less(n.keys[idx], k) {
+		idx++
+	}
+	return n, idx
+}
+
+// upperBound returns n and the index of the first key > k, or
+// len(n.keys) if every key in n is <= k.
+func (n *leafNode[K, V]) upperBound(k K) (*leafNode[K, V], int) {
+	idx := 0
+	for idx < len(n.keys) && !n.less(k, n.keys[idx]) {
+		idx++
+	}
+	return n, idx
+}
+
+func (n *leafNode[K, V]) leftmostLeaf() *leafNode[K, V] {
+	return n
+}
+
+func (n *leafNode[K, V]) rightmostLeaf() *leafNode[K, V] {
+	return n
+}
+
+// Insert adds a new key-value pair to the leaf in sorted
+// order. If allowDuplicates is false and k already has an
+// entry, that entry's value is overwritten in place instead. If
+// the leaf is already full after inserting, it splits in half
+// and returns the key promoted to the parent along with the new
+// right sibling.
+func (n *leafNode[K, V]) Insert(gen uint64, k K, v V) (node[K, V], *K, node[K, V], error) {
+	n = n.ensureOwned(gen)
+
+	idx := 0
+	for idx < len(n.keys) && n.less(n.keys[idx], k) {
+		idx++
+	}
+	if !n.allowDuplicates && idx < len(n.keys) && !n.less(k, n.keys[idx]) {
+		n.pointers[idx] = v
+		return n, nil, nil, n.persistSelf()
+	}
+	// Advance past any existing run of keys equal to k so a
+	// duplicate is appended after them, preserving insertion
+	// order within the run.
+	for idx < len(n.keys) && !n.less(k, n.keys[idx]) {
+		idx++
+	}
+
+	tmpKeys := make([]K, 0, len(n.keys)+1)
+	tmpKeys = append(tmpKeys, n.keys[:idx]...)
+	tmpKeys = append(tmpKeys, k)
+	tmpKeys = append(tmpKeys, n.keys[idx:]...)
+
+	tmpValues := make([]V, 0, len(n.pointers)+1)
+	tmpValues = append(tmpValues, n.pointers[:idx]...)
+	tmpValues = append(tmpValues, v)
+	tmpValues = append(tmpValues, n.pointers[idx:]...)
+
+	if len(tmpKeys) <= n.order {
+		n.keys = append(n.keys[:0], tmpKeys...)
+		n.pointers = append(n.pointers[:0], tmpValues...)
+		return n, nil, nil, n.persistSelf()
+	}
+
+	// n is full: split it, giving the right half to a new
+	// sibling and promoting its first key up to our caller.
+	mid := (len(tmpKeys) + 1) / 2
+
+	if n.allowDuplicates {
+		// The separator promoted to the parent must be strictly
+		// greater than every key kept in the left sibling, or a
+		// run of equal keys spanning the split point would
+		// become unreachable through the parent for the key it
+		// was split on. Prefer growing the left side to keep the
+		// run together; if the run reaches the end of tmpKeys,
+		// shrink the left side instead so the run moves to the
+		// right sibling in one piece. A run long enough to span
+		// the entire node (more duplicates than the order
+		// allows) cannot be separated this way and falls back to
+		// the original midpoint.
+		orig := mid
+		for mid < len(tmpKeys)-1 && !n.less(tmpKeys[mid-1], tmpKeys[mid]) {
+			mid++
+		}
+		if !n.less(tmpKeys[mid-1], tmpKeys[mid]) {
+			mid = orig
+			for mid > 1 && !n.less(tmpKeys[mid-1], tmpKeys[mid]) {
+				mid--
+			}
+			if !n.less(tmpKeys[mid-1], tmpKeys[mid]) {
+				mid = orig
+			}
+		}
+	}
+
+	right, err := newLeafNode[K, V](n.order, n.less, n.allowDuplicates, gen, n.store, n.keyCodec, n.valueCodec)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	right.keys = append(right.keys, tmpKeys[mid:]...)
+	right.pointers = append(right.pointers, tmpValues[mid:]...)
+	right.next = n.next
+	right.prev = n
+
+	n.keys = append(n.keys[:0], tmpKeys[:mid]...)
+	n.pointers = append(n.pointers[:0], tmpValues[:mid]...)
+	n.next = right
+
+	promoted := tmpKeys[mid]
+
+	// prev pointers are an in-memory-only convenience for
+	// Cursor traversal; they are not part of the on-disk
+	// encoding and so need no separate persistSelf call. The
+	// old next-neighbor is owned (copied if shared with an open
+	// Snapshot) before its prev pointer is repointed at right, so
+	// a reader holding the pre-split neighbor through a Snapshot
+	// still sees its original, unmutated prev.
+	if old, ok := right.next.(*leafNode[K, V]); ok {
+		old = old.ensureOwned(gen)
+		old.prev = right
+		right.next = old
+	}
+
+	// The new sibling must be durable before this node starts
+	// pointing at it.
+	if err := right.persistSelf(); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := n.persistSelf(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return n, &promoted, right, nil
+}
+
+// Update sets the value for every key in the leaf (and any
+// following leaves) that matches k. Every leaf the walk steps
+// into is owned before it is mutated, the same as the receiver,
+// so a duplicate-key run spanning more than one leaf is fully
+// copy-on-write safe, not just its first leaf.
+func (n *leafNode[K, V]) Update(gen uint64, k K, v V) (node[K, V], error) {
+	n = n.ensureOwned(gen)
+
+	cur := node[K, V](n)
+	leaf := n
+	for leaf != nil {
+		changed := false
+		for i, key := range leaf.keys {
+			if !leaf.less(key, k) && !leaf.less(k, key) {
+				leaf.pointers[i] = v
+				changed = true
+			}
+		}
+		if changed {
+			if err := leaf.persistSelf(); err != nil {
+				return cur, err
+			}
+		}
+		if len(leaf.keys) == 0 || leaf.less(leaf.keys[len(leaf.keys)-1], k) || leaf.less(k, leaf.keys[len(leaf.keys)-1]) {
+			break
+		}
+		next, _ := leaf.next.(*leafNode[K, V])
+		if next == nil {
+			break
+		}
+		next = next.ensureOwned(gen)
+		next.prev = leaf
+		leaf.next = next
+		leaf = next
+	}
+	return cur, nil
+}
+
+// Delete removes every key-value pair in the leaf (and any
+// following leaves) whose key matches k. Underfull nodes are
+// left in place rather than merged or redistributed; the caller
+// may leave this leaf with zero keys, in which case the parent
+// internalNode's Delete drops it from the tree and frees its
+// page rather than leaving an empty leaf in place (see
+// internalNode.dropEmptyLeafChild). As with Update, every leaf
+// the walk steps into is owned before it is mutated, so a
+// duplicate-key run spanning more than one leaf is fully
+// copy-on-write safe.
+func (n *leafNode[K, V]) Delete(gen uint64, k K) (node[K, V], error) {
+	n = n.ensureOwned(gen)
+	self := node[K, V](n)
+	cur := n
+	for cur != nil {
+		lastMatched := len(cur.keys) > 0 &&
+			!cur.less(cur.keys[len(cur.keys)-1], k) && !cur.less(k, cur.keys[len(cur.keys)-1])
+
+		keys := make([]K, 0, len(cur.keys))
+		vals := make([]V, 0, len(cur.pointers))
+		changed := false
+		for i, key := range cur.keys {
+			if !cur.less(key, k) && !cur.less(k, key) {
+				changed = true
+				continue
+			}
+			keys = append(keys, key)
+			vals = append(vals, cur.pointers[i])
+		}
+
+		if changed {
+			cur.keys = append(cur.keys[:0], keys...)
+			cur.pointers = append(cur.pointers[:0], vals...)
+			if err := cur.persistSelf(); err != nil {
+				return self, err
+			}
+		}
+
+		if !lastMatched {
+			break
+		}
+		next, _ := cur.next.(*leafNode[K, V])
+		if next == nil {
+			break
+		}
+		next = next.ensureOwned(gen)
+		next.prev = cur
+		cur.next = next
+		cur = next
+	}
+	return self, nil
+}
+
+// DeleteOne removes the first key-value pair in the leaf (and
+// any following leaves, for a duplicate-key run) whose key
+// equals k and whose value matches v under eq, stopping as soon
+// as a key greater than k rules out any further match. As with
+// Delete, every leaf the walk steps into is owned before it is
+// mutated.
+func (n *leafNode[K, V]) DeleteOne(gen uint64, k K, v V, eq func(V, V) bool) (node[K, V], bool, error) {
+	n = n.ensureOwned(gen)
+	self := node[K, V](n)
+	cur := n
+	for cur != nil {
+		for i, key := range cur.keys {
+			if cur.less(k, key) {
+				return self, false, nil
+			}
+			if cur.less(key, k) {
+				continue
+			}
+			if eq(cur.pointers[i], v) {
+				cur.keys = append(cur.keys[:i], cur.keys[i+1:]...)
+				cur.pointers = append(cur.pointers[:i], cur.pointers[i+1:]...)
+				if err := cur.persistSelf(); err != nil {
+					return self, false, err
+				}
+				return self, true, nil
+			}
+		}
+		if len(cur.keys) == 0 || cur.less(cur.keys[len(cur.keys)-1], k) || cur.less(k, cur.keys[len(cur.keys)-1]) {
+			break
+		}
+		next, _ := cur.next.(*leafNode[K, V])
+		if next == nil {
+			break
+		}
+		next = next.ensureOwned(gen)
+		next.prev = cur
+		cur.next = next
+		cur = next
+	}
+	return self, false, nil
+}
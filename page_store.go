@@ -0,0 +1,137 @@
+package bplus
+
+import (
+	"fmt"
+	"os"
+)
+
+// pageID identifies a fixed-size page within a PageStore. The
+// zero value, nilPageID, never refers to a real page.
+type pageID uint64
+
+const nilPageID pageID = 0
+
+// PageStore is a block/page-oriented backing store a BPlusTree
+// can persist its nodes to. Page 0 is reserved for the tree's
+// header and must not be returned by Allocate.
+type PageStore interface {
+	// Allocate reserves a new page and returns its id.
+	Allocate() (pageID, error)
+
+	// Read returns the contents of the page with the given id.
+	Read(pageID) ([]byte, error)
+
+	// Write stores data as the contents of the page with the
+	// given id.
+	Write(pageID, []byte) error
+
+	// Free releases a page so its id may be reused by a later
+	// Allocate call.
+	Free(pageID) error
+
+	// Sync flushes any buffered writes to durable storage.
+	Sync() error
+}
+
+// headerPageID is the fixed page holding the tree's header.
+const headerPageID pageID = 0
+
+// DefaultPageSize is the page size used by NewFilePageStore
+// when none is specified.
+const DefaultPageSize = 4096
+
+// FilePageStore is a PageStore backed by a single file of
+// fixed-size pages.
+type FilePageStore struct {
+	f        *os.File
+	pageSize int
+	next     pageID
+	free     []pageID
+}
+
+// NewFilePageStore opens (creating if necessary) a file-backed
+// PageStore at path, using pageSize-byte pages. If the file
+// already contains pages, allocation resumes after the last one.
+func NewFilePageStore(path string, pageSize int) (*FilePageStore, error) {
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	pageCount := pageID(info.Size() / int64(pageSize))
+	next := pageCount
+	if next == nilPageID {
+		// Page 0 is reserved for the header.
+		next = 1
+	}
+
+	return &FilePageStore{
+		f:        f,
+		pageSize: pageSize,
+		next:     next,
+	}, nil
+}
+
+// Allocate reserves a new page, reusing a freed page id if one
+// is available.
+func (s *FilePageStore) Allocate() (pageID, error) {
+	if n := len(s.free); n > 0 {
+		id := s.free[n-1]
+		s.free = s.free[:n-1]
+		return id, nil
+	}
+	id := s.next
+	s.next++
+	return id, nil
+}
+
+// Read returns the raw, pageSize-length contents of page id.
+func (s *FilePageStore) Read(id pageID) ([]byte, error) {
+	buf := make([]byte, s.pageSize)
+	off := int64(id) * int64(s.pageSize)
+	if _, err := s.f.ReadAt(buf, off); err != nil {
+		return nil, fmt.Errorf("bplus: read page %d: %w", id, err)
+	}
+	return buf, nil
+}
+
+// Write stores data as the contents of page id, zero-padded
+// to a full page. data must not be longer than the page size.
+func (s *FilePageStore) Write(id pageID, data []byte) error {
+	if len(data) > s.pageSize {
+		return fmt.Errorf("bplus: page %d: data exceeds page size (%d > %d)", id, len(data), s.pageSize)
+	}
+	buf := make([]byte, s.pageSize)
+	copy(buf, data)
+	off := int64(id) * int64(s.pageSize)
+	if _, err := s.f.WriteAt(buf, off); err != nil {
+		return fmt.Errorf("bplus: write page %d: %w", id, err)
+	}
+	return nil
+}
+
+// Free marks id as reusable by a future Allocate call.
+func (s *FilePageStore) Free(id pageID) error {
+	s.free = append(s.free, id)
+	return nil
+}
+
+// Sync flushes the underlying file to disk.
+func (s *FilePageStore) Sync() error {
+	return s.f.Sync()
+}
+
+// Close closes the underlying file.
+func (s *FilePageStore) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,422 @@
+package bplus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	leafPageType     byte = 'L'
+	internalPageType byte = 'I'
+)
+
+var headerMagic = [4]byte{'B', 'P', 'L', '2'}
+
+const headerSize = 4 + 4 + 1 + 8 // magic + order + allowDuplicates + root pageID
+
+// Codec encodes and decodes values of type T to and from the
+// bytes a PageStore persists. BPlusTree[K, V] needs one for K
+// and one for V to support NewPersistentBPlusTree and Open.
+type Codec[T any] struct {
+	Encode func(T) ([]byte, error)
+	Decode func([]byte) (T, error)
+}
+
+// NewPersistentBPlusTree creates a new, empty B+ tree backed by
+// store. The tree's header (order, allowDuplicates, and root
+// page) is written to store immediately. keyCodec and
+// valueCodec are used to serialize keys and values to store's
+// pages.
+func NewPersistentBPlusTree[K, V any](order int, less func(a, b K) bool, allowDuplicates bool, store PageStore, keyCodec Codec[K], valueCodec Codec[V]) (*BPlusTree[K, V], error) {
+	if order <= 0 {
+		panic("tree order must be >= 1")
+	}
+	if store == nil {
+		panic("store must not be nil")
+	}
+
+	root, err := newLeafNode[K, V](order, less, allowDuplicates, 0, store, &keyCodec, &valueCodec)
+	if err != nil {
+		return nil, err
+	}
+	if err := root.persistSelf(); err != nil {
+		return nil, err
+	}
+
+	t := &BPlusTree[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		root:            root,
+		store:           store,
+		keyCodec:        &keyCodec,
+		valueCodec:      &valueCodec,
+	}
+	if err := t.persistRoot(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Open reconstructs a B+ tree previously written to store by
+// reading its header and recursively loading every node
+// reachable from the root. The full tree is loaded into memory
+// at open time; nodes are not paged in lazily.
+func Open[K, V any](store PageStore, less func(a, b K) bool, keyCodec Codec[K], valueCodec Codec[V]) (*BPlusTree[K, V], error) {
+	data, err := store.Read(headerPageID)
+	if err != nil {
+		return nil, err
+	}
+	order, allowDuplicates, rootID, err := decodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &loadContext[K, V]{
+		store:           store,
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		keyCodec:        &keyCodec,
+		valueCodec:      &valueCodec,
+		leavesByID:      make(map[pageID]*leafNode[K, V]),
+		pendingNext:     make(map[pageID]pageID),
+	}
+	root, err := ctx.load(rootID)
+	if err != nil {
+		return nil, err
+	}
+	for id, nextID := range ctx.pendingNext {
+		next := ctx.leavesByID[nextID]
+		ctx.leavesByID[id].next = next
+		next.prev = ctx.leavesByID[id]
+	}
+
+	return &BPlusTree[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		root:            root,
+		store:           store,
+		keyCodec:        &keyCodec,
+		valueCodec:      &valueCodec,
+	}, nil
+}
+
+// Sync flushes the tree's backing store, if any, to durable
+// storage.
+func (t *BPlusTree[K, V]) Sync() error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.Sync()
+}
+
+// persistRoot writes the tree's header page (order and the
+// current root's page id). It is a no-op for an in-memory tree.
+func (t *BPlusTree[K, V]) persistRoot() error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.Write(headerPageID, encodeHeader(t.order, t.allowDuplicates, nodePageID[K, V](t.root)))
+}
+
+// nodePageID returns the page id backing n, or nilPageID if n
+// is not a persisted node.
+func nodePageID[K, V any](n node[K, V]) pageID {
+	switch v := n.(type) {
+	case *leafNode[K, V]:
+		return v.pageID
+	case *internalNode[K, V]:
+		return v.pageID
+	default:
+		return nilPageID
+	}
+}
+
+func encodeHeader(order int, allowDuplicates bool, rootID pageID) []byte {
+	var buf bytes.Buffer
+	buf.Write(headerMagic[:])
+	var orderBuf [4]byte
+	binary.BigEndian.PutUint32(orderBuf[:], uint32(order))
+	buf.Write(orderBuf[:])
+	if allowDuplicates {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	var rootBuf [8]byte
+	binary.BigEndian.PutUint64(rootBuf[:], uint64(rootID))
+	buf.Write(rootBuf[:])
+	return buf.Bytes()
+}
+
+func decodeHeader(data []byte) (order int, allowDuplicates bool, rootID pageID, err error) {
+	if len(data) < headerSize {
+		return 0, false, 0, fmt.Errorf("bplus: header page too short (%d bytes)", len(data))
+	}
+	if !bytes.Equal(data[:4], headerMagic[:]) {
+		return 0, false, 0, fmt.Errorf("bplus: bad header magic %q", data[:4])
+	}
+	order = int(binary.BigEndian.Uint32(data[4:8]))
+	allowDuplicates = data[8] != 0
+	rootID = pageID(binary.BigEndian.Uint64(data[9:17]))
+	return order, allowDuplicates, rootID, nil
+}
+
+// persistSelf writes n's current contents to its backing page.
+// It is a no-op for an in-memory node.
+func (n *leafNode[K, V]) persistSelf() error {
+	if n.store == nil {
+		return nil
+	}
+	data, err := encodeLeaf(n)
+	if err != nil {
+		return err
+	}
+	if err := n.store.Write(n.pageID, data); err != nil {
+		return err
+	}
+	n.dirty = false
+	return nil
+}
+
+// persistSelf writes n's current contents to its backing page.
+// It is a no-op for an in-memory node.
+func (n *internalNode[K, V]) persistSelf() error {
+	if n.store == nil {
+		return nil
+	}
+	data, err := encodeInternal(n)
+	if err != nil {
+		return err
+	}
+	if err := n.store.Write(n.pageID, data); err != nil {
+		return err
+	}
+	n.dirty = false
+	return nil
+}
+
+func encodeLeaf[K, V any](n *leafNode[K, V]) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(leafPageType)
+	var countBuf [4]byte
+	binary.BigEndian.PutUint32(countBuf[:], uint32(len(n.keys)))
+	buf.Write(countBuf[:])
+
+	for i, k := range n.keys {
+		kb, err := n.keyCodec.Encode(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := n.valueCodec.Encode(n.pointers[i])
+		if err != nil {
+			return nil, err
+		}
+		writeLenPrefixed(&buf, kb)
+		writeLenPrefixed(&buf, vb)
+	}
+
+	nextID := nilPageID
+	if next, ok := n.next.(*leafNode[K, V]); ok {
+		nextID = next.pageID
+	}
+	var nextBuf [8]byte
+	binary.BigEndian.PutUint64(nextBuf[:], uint64(nextID))
+	buf.Write(nextBuf[:])
+
+	return buf.Bytes(), nil
+}
+
+func decodeLeaf[K, V any](data []byte, order int, less func(a, b K) bool, allowDuplicates bool, store PageStore, keyCodec *Codec[K], valueCodec *Codec[V], id pageID) (*leafNode[K, V], pageID, error) {
+	if len(data) < 5 || data[0] != leafPageType {
+		return nil, 0, fmt.Errorf("bplus: page %d is not a leaf page", id)
+	}
+	count := int(binary.BigEndian.Uint32(data[1:5]))
+
+	n := &leafNode[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		keys:            make([]K, 0, order),
+		pointers:        make([]V, 0, order),
+		store:           store,
+		keyCodec:        keyCodec,
+		valueCodec:      valueCodec,
+		pageID:          id,
+	}
+
+	r := bytes.NewReader(data[5:])
+	for i := 0; i < count; i++ {
+		kb, err := readLenPrefixed(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		vb, err := readLenPrefixed(r)
+		if err != nil {
+			return nil, 0, err
+		}
+		k, err := keyCodec.Decode(kb)
+		if err != nil {
+			return nil, 0, err
+		}
+		v, err := valueCodec.Decode(vb)
+		if err != nil {
+			return nil, 0, err
+		}
+		n.keys = append(n.keys, k)
+		n.pointers = append(n.pointers, v)
+	}
+
+	var nextBuf [8]byte
+	if _, err := r.Read(nextBuf[:]); err != nil {
+		return nil, 0, fmt.Errorf("bplus: page %d: reading next pointer: %w", id, err)
+	}
+	nextID := pageID(binary.BigEndian.Uint64(nextBuf[:]))
+
+	return n, nextID, nil
+}
+
+func encodeInternal[K, V any](n *internalNode[K, V]) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(internalPageType)
+	var countBuf [4]byte
+	binary.BigEndian.PutUint32(countBuf[:], uint32(len(n.keys)))
+	buf.Write(countBuf[:])
+
+	for _, k := range n.keys {
+		kb, err := n.keyCodec.Encode(k)
+		if err != nil {
+			return nil, err
+		}
+		writeLenPrefixed(&buf, kb)
+	}
+	for _, p := range n.pointers {
+		var idBuf [8]byte
+		binary.BigEndian.PutUint64(idBuf[:], uint64(nodePageID[K, V](p)))
+		buf.Write(idBuf[:])
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeInternalHeader decodes an internal page's keys and the
+// page ids of its children. The caller is responsible for
+// loading and attaching each child node.
+func decodeInternalHeader[K, V any](data []byte, order int, less func(a, b K) bool, allowDuplicates bool, store PageStore, keyCodec *Codec[K], id pageID) (*internalNode[K, V], []pageID, error) {
+	if len(data) < 5 || data[0] != internalPageType {
+		return nil, nil, fmt.Errorf("bplus: page %d is not an internal page", id)
+	}
+	count := int(binary.BigEndian.Uint32(data[1:5]))
+
+	n := &internalNode[K, V]{
+		order:           order,
+		less:            less,
+		allowDuplicates: allowDuplicates,
+		keys:            make([]K, 0, order),
+		pointers:        make([]node[K, V], 0, order+1),
+		store:           store,
+		keyCodec:        keyCodec,
+		pageID:          id,
+	}
+
+	r := bytes.NewReader(data[5:])
+	for i := 0; i < count; i++ {
+		kb, err := readLenPrefixed(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		k, err := keyCodec.Decode(kb)
+		if err != nil {
+			return nil, nil, err
+		}
+		n.keys = append(n.keys, k)
+	}
+
+	childIDs := make([]pageID, count+1)
+	for i := 0; i <= count; i++ {
+		var idBuf [8]byte
+		if _, err := r.Read(idBuf[:]); err != nil {
+			return nil, nil, fmt.Errorf("bplus: page %d: reading child pointer: %w", id, err)
+		}
+		childIDs[i] = pageID(binary.BigEndian.Uint64(idBuf[:]))
+	}
+
+	return n, childIDs, nil
+}
+
+func writeLenPrefixed(buf *bytes.Buffer, b []byte) {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+	buf.Write(lenBuf[:n])
+	buf.Write(b)
+}
+
+func readLenPrefixed(r *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, l)
+	if _, err := r.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// loadContext tracks state while recursively loading a tree
+// from a PageStore, so that leaf `next` pointers (which may
+// reference a page not yet decoded) can be patched up once the
+// whole reachable set of nodes has been loaded.
+type loadContext[K, V any] struct {
+	store           PageStore
+	order           int
+	less            func(a, b K) bool
+	allowDuplicates bool
+	keyCodec        *Codec[K]
+	valueCodec      *Codec[V]
+	leavesByID      map[pageID]*leafNode[K, V]
+	pendingNext     map[pageID]pageID
+}
+
+func (c *loadContext[K, V]) load(id pageID) (node[K, V], error) {
+	data, err := c.store.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("bplus: page %d is empty", id)
+	}
+
+	switch data[0] {
+	case leafPageType:
+		leaf, nextID, err := decodeLeaf[K, V](data, c.order, c.less, c.allowDuplicates, c.store, c.keyCodec, c.valueCodec, id)
+		if err != nil {
+			return nil, err
+		}
+		c.leavesByID[id] = leaf
+		if nextID != nilPageID {
+			c.pendingNext[id] = nextID
+		}
+		return leaf, nil
+
+	case internalPageType:
+		in, childIDs, err := decodeInternalHeader[K, V](data, c.order, c.less, c.allowDuplicates, c.store, c.keyCodec, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, childID := range childIDs {
+			child, err := c.load(childID)
+			if err != nil {
+				return nil, err
+			}
+			in.pointers = append(in.pointers, child)
+		}
+		return in, nil
+
+	default:
+		return nil, fmt.Errorf("bplus: page %d has unknown page type %q", id, data[0])
+	}
+}
@@ -0,0 +1,137 @@
+package bplus
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+)
+
+func intCodec() Codec[int] {
+	return Codec[int]{
+		Encode: func(v int) ([]byte, error) {
+			var buf [8]byte
+			binary.BigEndian.PutUint64(buf[:], uint64(v))
+			return buf[:], nil
+		},
+		Decode: func(b []byte) (int, error) {
+			return int(binary.BigEndian.Uint64(b)), nil
+		},
+	}
+}
+
+// TestReopenPersistsContents writes a tree to a FilePageStore,
+// closes and reopens the file, and checks that every key-value
+// pair inserted before the reopen is still there afterward.
+func TestReopenPersistsContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	store, err := NewFilePageStore(path, DefaultPageSize)
+	if err != nil {
+		t.Fatalf("NewFilePageStore: %v", err)
+	}
+
+	less := func(a, b int) bool { return a < b }
+	tree, err := NewPersistentBPlusTree[int, int](4, less, false, store, intCodec(), intCodec())
+	if err != nil {
+		t.Fatalf("NewPersistentBPlusTree: %v", err)
+	}
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		if err := tree.Insert(i, i*10); err != nil {
+			t.Fatalf("Insert(%d): %v", i, err)
+		}
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewFilePageStore(path, DefaultPageSize)
+	if err != nil {
+		t.Fatalf("NewFilePageStore (reopen): %v", err)
+	}
+	defer reopened.Close()
+
+	loaded, err := Open[int, int](reopened, less, intCodec(), intCodec())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	for i := 0; i < n; i++ {
+		v, err := loaded.Search(i)
+		if err != nil {
+			t.Fatalf("Search(%d): %v", i, err)
+		}
+		if v != i*10 {
+			t.Errorf("Search(%d) = %d, want %d", i, v, i*10)
+		}
+	}
+}
+
+// TestReopenAfterDeleteFreesLeafPage checks that deleting every
+// key, so that dropEmptyLeafChild frees emptied leaf pages, then
+// reopening and inserting enough new keys to need another page
+// succeeds. FilePageStore keeps its free list in memory only, so
+// the pages freed before the reopen are not reused after it; the
+// new keys go to freshly allocated pages.
+func TestReopenAfterDeleteFreesLeafPage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tree.db")
+
+	store, err := NewFilePageStore(path, DefaultPageSize)
+	if err != nil {
+		t.Fatalf("NewFilePageStore: %v", err)
+	}
+
+	less := func(a, b int) bool { return a < b }
+	tree, err := NewPersistentBPlusTree[int, int](4, less, false, store, intCodec(), intCodec())
+	if err != nil {
+		t.Fatalf("NewPersistentBPlusTree: %v", err)
+	}
+
+	const n = 50
+	for i := 0; i < n; i++ {
+		if err := tree.Insert(i, i); err != nil {
+			t.Fatalf("Insert(%d): %v", i, err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if err := tree.Delete(i); err != nil {
+			t.Fatalf("Delete(%d): %v", i, err)
+		}
+	}
+	if err := tree.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := NewFilePageStore(path, DefaultPageSize)
+	if err != nil {
+		t.Fatalf("NewFilePageStore (reopen): %v", err)
+	}
+	defer reopened.Close()
+
+	loaded, err := Open[int, int](reopened, less, intCodec(), intCodec())
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	for i := n; i < n+n; i++ {
+		if err := loaded.Insert(i, i); err != nil {
+			t.Fatalf("Insert(%d) after reopen: %v", i, err)
+		}
+	}
+	for i := n; i < n+n; i++ {
+		v, err := loaded.Search(i)
+		if err != nil {
+			t.Fatalf("Search(%d): %v", i, err)
+		}
+		if v != i {
+			t.Errorf("Search(%d) = %d, want %d", i, v, i)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package bplus
+
+// Snapshot is an immutable, point-in-time view of a BPlusTree's
+// key-value pairs. It supports Search, SearchRange, and cursor
+// iteration concurrently with writers on the tree it was taken
+// from: a Snapshot's nodes are never mutated once published, so
+// reading from one never blocks on, or is blocked by, the
+// tree's internal mutex. Release a Snapshot with Close once it
+// is no longer needed.
+type Snapshot[K, V any] struct {
+	root node[K, V]
+	less func(a, b K) bool
+}
+
+// Snapshot returns an immutable view of t as of this call. Any
+// Insert, Update, or Delete made to t afterward copies rather
+// than mutates a node still reachable from s, so s continues to
+// read the tree's contents at the moment Snapshot was called.
+//
+// Snapshot is O(1): it does not copy the tree or walk its nodes,
+// only bumps a generation counter so a later write knows, node
+// by node as it descends, whether a node was last owned before
+// or after this call and so needs copying. For a store-backed
+// tree, that copy-on-write protection covers the in-memory node
+// graph only; a write that copies a node still persists the
+// copy to that node's original page, so a Snapshot of a
+// persistent tree does not protect the backing store the way it
+// protects memory.
+func (t *BPlusTree[K, V]) Snapshot() *Snapshot[K, V] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.snapGen++
+	return &Snapshot[K, V]{root: t.root, less: t.less}
+}
+
+// Clone returns a new, independent BPlusTree that starts out
+// sharing structure with t via the same copy-on-write mechanism
+// as Snapshot: writes to the clone or to t copy rather than
+// mutate shared nodes, so afterward neither tree's changes are
+// visible through the other. Cloning is O(1) in the same way
+// Snapshot is, with the same store-backed-tree caveat.
+func (t *BPlusTree[K, V]) Clone() *BPlusTree[K, V] {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.snapGen++
+	return &BPlusTree[K, V]{
+		snapGen:         t.snapGen,
+		order:           t.order,
+		less:            t.less,
+		allowDuplicates: t.allowDuplicates,
+		root:            t.root,
+		store:           t.store,
+		keyCodec:        t.keyCodec,
+		valueCodec:      t.valueCodec,
+	}
+}
+
+// Search returns the first value with the matching key in the
+// snapshot.
+func (s *Snapshot[K, V]) Search(k K) (V, error) {
+	return s.root.Search(k)
+}
+
+// SearchRange returns all values with a key in the inclusive
+// range [k1, k2] in the snapshot.
+func (s *Snapshot[K, V]) SearchRange(k1, k2 K) ([]V, error) {
+	return s.root.SearchRange(k1, k2)
+}
+
+// Cursor returns a new Cursor over s, positioned before the
+// first key-value pair. Call Seek, SeekFirst, or SeekLast
+// before Next or Prev to establish a starting position.
+func (s *Snapshot[K, V]) Cursor() *Cursor[K, V] {
+	return &Cursor[K, V]{src: s}
+}
+
+func (s *Snapshot[K, V]) cursorRoot() node[K, V]      { return s.root }
+func (s *Snapshot[K, V]) cursorLess() func(K, K) bool { return s.less }
+
+// lock and unlock are no-ops: a Snapshot's nodes are immutable
+// once published, so reading them never needs to serialize
+// against a writer.
+func (s *Snapshot[K, V]) lock()   {}
+func (s *Snapshot[K, V]) unlock() {}
+
+// Ascend calls fn for every key-value pair in the snapshot with
+// a key in the inclusive range [from, to], in ascending order,
+// stopping early if fn returns false.
+func (s *Snapshot[K, V]) Ascend(from, to K, fn func(K, V) bool) error {
+	c := s.Cursor()
+	c.Seek(from)
+	for {
+		k, v, ok := c.Next()
+		if !ok || s.less(to, k) {
+			return nil
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+}
+
+// Descend calls fn for every key-value pair in the snapshot with
+// a key in the inclusive range [from, to], in descending order,
+// stopping early if fn returns false.
+func (s *Snapshot[K, V]) Descend(from, to K, fn func(K, V) bool) error {
+	c := s.Cursor()
+	c.leaf, c.idx = upperBoundLeaf(s.root, s.less, to)
+	for {
+		k, v, ok := c.Prev()
+		if !ok || s.less(k, from) {
+			return nil
+		}
+		if !fn(k, v) {
+			return nil
+		}
+	}
+}
+
+// Close releases the snapshot's reference to the tree's nodes,
+// allowing any of them exclusively reachable from it to be
+// garbage-collected. A closed Snapshot must not be used again.
+func (s *Snapshot[K, V]) Close() {
+	s.root = nil
+}
@@ -0,0 +1,63 @@
+package bplus
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkInsertAlone measures Insert throughput with no
+// concurrent readers, as a baseline for
+// BenchmarkInsertWithConcurrentSnapshotReaders.
+func BenchmarkInsertAlone(b *testing.B) {
+	t := NewBPlusTree[int, int](64, func(a, b int) bool { return a < b }, false)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Insert(i, i)
+	}
+}
+
+// BenchmarkInsertWithConcurrentSnapshotReaders measures Insert
+// throughput on the same tree while several goroutines
+// continuously take a Snapshot and read through it. Only the
+// Snapshot call itself takes t.mu; reads through the Snapshot
+// never do (see Snapshot.lock), so this should run at roughly
+// the same speed as
+// BenchmarkInsertAlone rather than being slowed down by reader
+// traffic.
+func BenchmarkInsertWithConcurrentSnapshotReaders(b *testing.B) {
+	t := NewBPlusTree[int, int](64, func(a, b int) bool { return a < b }, false)
+	for i := 0; i < 1000; i++ {
+		t.Insert(i, i)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				snap := t.Snapshot()
+				snap.Search(500)
+				c := snap.Cursor()
+				c.SeekFirst()
+				for n := 0; n < 10; n++ {
+					if _, _, ok := c.Next(); !ok {
+						break
+					}
+				}
+				snap.Close()
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		t.Insert(1000+i, i)
+	}
+	b.StopTimer()
+
+	stop.Store(true)
+	wg.Wait()
+}